@@ -0,0 +1,209 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd_recipes
+
+import (
+	"sync"
+)
+
+// CACHE RECIPE
+//
+// This recipe wraps a ServiceTracker with a synchronous, in-memory view of
+// the instances it reports. Where ServiceTracker only offers an
+// asynchronous channel, Cache lets any number of local subsystems share a
+// single etcd watch and perform synchronous lookups (Get/List/Len) against
+// the last known-good set of instances, without each one having to run its
+// own Start() and keep its own copy in sync.
+
+// A descriptor structure for the cache operation.
+type Cache struct {
+	mu sync.RWMutex
+
+	// The underlying ServiceTracker this cache observes.
+	tracker *ServiceTracker
+
+	// The latest set of instances, keyed by node key.
+	byKey map[string]Pair
+
+	// Set when the underlying tracker has reported an error, cleared on
+	// the next successful update. Callers can consult this to decide
+	// whether to keep serving the last known-good data.
+	stale bool
+
+	// Subscribers to fan the latest instance list out to.
+	subscribers []chan<- []Pair
+}
+
+// Description:
+//     A constructor routine to instantiate a Cache on top of @tracker. This
+//     starts @tracker and begins populating the cache immediately; the
+//     caller does not call tracker.Start() itself.
+//
+// Parameters:
+//     @tracker - The ServiceTracker to cache.
+//
+// Return value:
+//     1. A pointer to the Cache instance.
+//     2. Error information, if any.
+func NewCache(tracker *ServiceTracker) (*Cache, error) {
+	c := &Cache{
+		tracker: tracker,
+		byKey:   make(map[string]Pair),
+	}
+
+	data, err := tracker.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for td := range data {
+			c.update(td)
+		}
+	}()
+
+	return c, nil
+}
+
+// Description:
+//     Applies a TrackerData update to the cache and fans the resulting
+//     instance list out to any Subscribe()d channels.
+//
+// Parameters:
+//     @td - The latest TrackerData reported by the tracker.
+//
+// Return value:
+//     None
+func (c *Cache) update(td TrackerData) {
+	c.mu.Lock()
+
+	if td.Err != nil {
+		c.stale = true
+		c.mu.Unlock()
+		return
+	}
+
+	byKey := make(map[string]Pair, len(td.Pairs))
+	for _, p := range td.Pairs {
+		byKey[p.Key] = p
+	}
+	c.byKey = byKey
+	c.stale = false
+
+	pairs := td.Pairs
+	subs := make([]chan<- []Pair, len(c.subscribers))
+	copy(subs, c.subscribers)
+	c.mu.Unlock()
+
+	// Send without blocking: a subscriber that isn't keeping up must not be
+	// allowed to wedge this goroutine, since it also drains the
+	// underlying tracker's channel. A subscriber that can't take an
+	// update just misses it.
+	for _, sub := range subs {
+		select {
+		case sub <- pairs:
+		default:
+		}
+	}
+}
+
+// Description:
+//     Looks up a single instance by its node key.
+//
+// Parameters:
+//     @key - The node key to look up.
+//
+// Return value:
+//     1. The Pair stored under @key, if any.
+//     2. Whether @key was present in the cache.
+func (c *Cache) Get(key string) (Pair, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.byKey[key]
+	return p, ok
+}
+
+// Description:
+//     Returns every instance currently held in the cache.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The current set of instances.
+func (c *Cache) List() []Pair {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	pairs := make([]Pair, 0, len(c.byKey))
+	for _, p := range c.byKey {
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// Description:
+//     Returns the number of instances currently held in the cache.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The instance count.
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.byKey)
+}
+
+// Description:
+//     Reports whether the cache is serving stale data, i.e. the underlying
+//     tracker's most recent notification was an error rather than a fresh
+//     set of instances.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. True if the cache is stale.
+func (c *Cache) Stale() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.stale
+}
+
+// Description:
+//     Registers @ch to receive the current instance list every time the
+//     cache is updated, letting multiple consumers share this Cache's
+//     single underlying tracker go-routine instead of each starting their
+//     own. Updates are posted to @ch without blocking, so @ch should be
+//     given enough buffer for the caller's consumption rate; an update is
+//     dropped for a subscriber that isn't ready to receive it rather than
+//     stalling the cache for everyone else.
+//
+// Parameters:
+//     @ch - The channel to post instance list updates to.
+//
+// Return value:
+//     None
+func (c *Cache) Subscribe(ch chan<- []Pair) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.subscribers = append(c.subscribers, ch)
+}
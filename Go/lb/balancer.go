@@ -0,0 +1,137 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// LOAD-BALANCER RECIPE
+//
+// This package builds a client-side load-balancer on top of the
+// ServiceTracker recipe. A ServiceTracker reports the current set of
+// instances that make up a distributed service; a Balancer picks one of
+// those instances for the caller to use, according to some selection
+// policy, and Retry walks fresh picks until the caller's operation
+// succeeds or the attempt budget is exhausted.
+package lb
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	etcd_recipes "github.com/ajityagaty/etcd-connector/Go"
+)
+
+// Returned by Next() when the balancer has no instances to choose from.
+var ErrNoInstances = errors.New("lb: no instances available")
+
+// A Balancer picks one instance out of the current set reported by a
+// ServiceTracker.
+type Balancer interface {
+	// Next returns the instance to use for the next request.
+	Next() (etcd_recipes.Pair, error)
+}
+
+// A descriptor structure shared by the Balancer implementations in this
+// package. It keeps the latest set of Pairs reported by a ServiceTracker,
+// refreshed in a background go-routine.
+type baseBalancer struct {
+	mu    sync.Mutex
+	pairs []etcd_recipes.Pair
+}
+
+// Description:
+//     Starts a go-routine that keeps @bb.pairs in sync with the instances
+//     reported by @tracker. The go-routine exits once @tracker's channel is
+//     closed.
+//
+// Parameters:
+//     @tracker - The ServiceTracker to observe.
+//
+// Return value:
+//     1. Error information, if any.
+func (bb *baseBalancer) watch(tracker *etcd_recipes.ServiceTracker) error {
+	data, err := tracker.Start()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for td := range data {
+			if td.Err != nil {
+				continue
+			}
+			bb.mu.Lock()
+			bb.pairs = td.Pairs
+			bb.mu.Unlock()
+		}
+	}()
+
+	return nil
+}
+
+// Description:
+//     Returns a snapshot of the instances currently known to the balancer.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The current set of instances.
+func (bb *baseBalancer) snapshot() []etcd_recipes.Pair {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	return bb.pairs
+}
+
+// Description:
+//     Retry repeatedly picks a fresh instance from @b and invokes @fn with
+//     it, retrying on failure until @fn succeeds, @max attempts have been
+//     made, or @timeout elapses.
+//
+// Parameters:
+//     @b       - The Balancer to pick instances from.
+//     @max     - The maximum number of attempts to make. Must be positive.
+//     @timeout - The overall deadline across all attempts.
+//     @fn      - The operation to perform against the picked instance.
+//
+// Return value:
+//     1. Error information, if any. The error from the final attempt is
+//        returned if all attempts are exhausted.
+func Retry(b Balancer, max int, timeout time.Duration, fn func(etcd_recipes.Pair) error) error {
+	if max <= 0 {
+		return errors.New("lb: max must be positive")
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	var lastErr error
+	for attempt := 0; attempt < max; attempt++ {
+		if time.Now().After(deadline) {
+			if lastErr != nil {
+				return lastErr
+			}
+			return errors.New("lb: retry timed out")
+		}
+
+		pair, err := b.Next()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if lastErr = fn(pair); lastErr == nil {
+			return nil
+		}
+	}
+
+	return lastErr
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lb
+
+import (
+	"math/rand"
+
+	etcd_recipes "github.com/ajityagaty/etcd-connector/Go"
+)
+
+// A Balancer that picks a uniformly random instance out of the set
+// reported by a ServiceTracker.
+type Random struct {
+	baseBalancer
+}
+
+// Description:
+//     A constructor routine for a Random balancer.
+//
+// Parameters:
+//     @tracker - The ServiceTracker whose instances will be balanced across.
+//
+// Return value:
+//     1. A pointer to the Random instance.
+//     2. Error information, if any.
+func NewRandom(tracker *etcd_recipes.ServiceTracker) (*Random, error) {
+	r := &Random{}
+	if err := r.watch(tracker); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Next returns a uniformly random instance from the current set.
+func (r *Random) Next() (etcd_recipes.Pair, error) {
+	pairs := r.snapshot()
+	if len(pairs) == 0 {
+		return etcd_recipes.Pair{}, ErrNoInstances
+	}
+
+	return pairs[rand.Intn(len(pairs))], nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lb
+
+import (
+	"sync/atomic"
+
+	etcd_recipes "github.com/ajityagaty/etcd-connector/Go"
+)
+
+// A Balancer that cycles through the instances reported by a ServiceTracker
+// in order.
+type RoundRobin struct {
+	baseBalancer
+	counter uint64
+}
+
+// Description:
+//     A constructor routine for a RoundRobin balancer.
+//
+// Parameters:
+//     @tracker - The ServiceTracker whose instances will be balanced across.
+//
+// Return value:
+//     1. A pointer to the RoundRobin instance.
+//     2. Error information, if any.
+func NewRoundRobin(tracker *etcd_recipes.ServiceTracker) (*RoundRobin, error) {
+	rr := &RoundRobin{}
+	if err := rr.watch(tracker); err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+// Next returns the next instance in the rotation.
+func (rr *RoundRobin) Next() (etcd_recipes.Pair, error) {
+	pairs := rr.snapshot()
+	if len(pairs) == 0 {
+		return etcd_recipes.Pair{}, ErrNoInstances
+	}
+
+	i := atomic.AddUint64(&rr.counter, 1)
+	return pairs[(i-1)%uint64(len(pairs))], nil
+}
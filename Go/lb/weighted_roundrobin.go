@@ -0,0 +1,152 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lb
+
+import (
+	"errors"
+	"sync"
+
+	etcd_recipes "github.com/ajityagaty/etcd-connector/Go"
+)
+
+// Extracts the weight to assign an instance from its Factory-decoded
+// value. Instances with weight <= 0 are treated as weight 1.
+type WeightFunc func(instance interface{}) int
+
+// An instance together with the weight it was last observed with.
+type weightedEntry struct {
+	pair    etcd_recipes.Pair
+	weight  int
+	current int
+}
+
+// A Balancer that picks instances in proportion to a weight parsed out of
+// each instance's decoded value (e.g. via the ServiceTracker's Factory),
+// using the smooth weighted round-robin algorithm: each call to Next()
+// bumps every entry's running total by its weight and hands out the entry
+// with the largest running total, then discounts it by the sum of all
+// weights. This spreads picks evenly over time rather than bursting
+// through one high-weight instance before moving to the next.
+type WeightedRoundRobin struct {
+	mu       sync.Mutex
+	entries  []*weightedEntry
+	weightFn WeightFunc
+}
+
+// Returned by NewWeightedRoundRobin when @tracker was not built with
+// NewServiceTrackerWithFactory, so its TrackerData.Instances would never be
+// populated and the balancer would silently stay empty forever.
+var ErrNoFactory = errors.New("lb: tracker has no Factory configured, WeightedRoundRobin needs decoded instances")
+
+// Description:
+//     A constructor routine for a WeightedRoundRobin balancer. @tracker
+//     must have been built with a Factory that decodes each instance's
+//     value into something @weightFn can extract a weight from.
+//
+// Parameters:
+//     @tracker  - The ServiceTracker whose instances will be balanced across.
+//     @weightFn - A hook that returns the weight for a decoded instance
+//                 value.
+//
+// Return value:
+//     1. A pointer to the WeightedRoundRobin instance.
+//     2. Error information, if any.
+func NewWeightedRoundRobin(tracker *etcd_recipes.ServiceTracker, weightFn WeightFunc) (*WeightedRoundRobin, error) {
+	if !tracker.HasFactory() {
+		return nil, ErrNoFactory
+	}
+	if weightFn == nil {
+		return nil, errors.New("lb: weightFn must not be nil")
+	}
+
+	wrr := &WeightedRoundRobin{weightFn: weightFn}
+
+	data, err := tracker.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for td := range data {
+			if td.Err != nil {
+				continue
+			}
+			wrr.update(td)
+		}
+	}()
+
+	return wrr, nil
+}
+
+// Description:
+//     Rebuilds wrr.entries from @td, preserving each surviving instance's
+//     current running total so in-flight weighting is not reset on every
+//     update.
+//
+// Parameters:
+//     @td - The latest TrackerData reported by the underlying tracker.
+//
+// Return value:
+//     None
+func (wrr *WeightedRoundRobin) update(td etcd_recipes.TrackerData) {
+	wrr.mu.Lock()
+	defer wrr.mu.Unlock()
+
+	prev := make(map[string]int, len(wrr.entries))
+	for _, e := range wrr.entries {
+		prev[e.pair.Key] = e.current
+	}
+
+	entries := make([]*weightedEntry, 0, len(td.Instances))
+	for _, inst := range td.Instances {
+		weight := 1
+		if inst.DecodeErr == nil {
+			if w := wrr.weightFn(inst.Value); w > 0 {
+				weight = w
+			}
+		}
+		entries = append(entries, &weightedEntry{
+			pair:    inst.Pair,
+			weight:  weight,
+			current: prev[inst.Pair.Key],
+		})
+	}
+
+	wrr.entries = entries
+}
+
+// Next returns the next instance according to the smooth weighted
+// round-robin schedule.
+func (wrr *WeightedRoundRobin) Next() (etcd_recipes.Pair, error) {
+	wrr.mu.Lock()
+	defer wrr.mu.Unlock()
+
+	if len(wrr.entries) == 0 {
+		return etcd_recipes.Pair{}, ErrNoInstances
+	}
+
+	total := 0
+	var best *weightedEntry
+	for _, e := range wrr.entries {
+		e.current += e.weight
+		total += e.weight
+		if best == nil || e.current > best.current {
+			best = e
+		}
+	}
+
+	best.current -= total
+	return best.pair, nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd_recipes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/Godeps/_workspace/src/golang.org/x/net/context"
+	"github.com/coreos/etcd/client"
+)
+
+// SERVICE-REGISTRAR RECIPE
+//
+// This recipe is the publishing counterpart to the ServiceTracker recipe. It
+// creates the well known ephemeral key that ServiceTracker observers are
+// watching for, and keeps that key alive for as long as the instance is
+// meant to be considered up. The following is how it works:
+//   - Register() creates an ephemeral key under @servicePath whose value is
+//     the instance descriptor supplied by the caller (e.g. IP address/port
+//     number etc...), set to expire after @ttl.
+//   - A background go-routine refreshes the key's TTL at an interval well
+//     inside @ttl so that transient delays do not cause the key to expire.
+//     If a refresh fails the go-routine falls back to re-registering the key
+//     from scratch, backing off exponentially between attempts.
+//   - Deregister() removes the key and stops the background go-routine.
+
+// A descriptor structure for the service registration operation.
+type ServiceRegistrar struct {
+	// Pointer to the etcd connection descriptor.
+	ec *EtcdConnector
+
+	// Path under which the service instance key will be created.
+	servicePath string
+
+	// The unique identity of this instance under @servicePath.
+	instanceID string
+
+	// The value stored in the instance's ephemeral key.
+	value string
+
+	// The TTL on the instance's ephemeral key.
+	ttl time.Duration
+
+	// The cadence at which the refresh go-routine wakes up and renews
+	// the TTL. Defaults to ttl/2.
+	refreshInterval time.Duration
+
+	// Guards value and registered against concurrent Register/Deregister/
+	// UpdateValue/refresh access.
+	mu sync.Mutex
+
+	// Channel used to signal the refresh go-routine to stop.
+	stopCh chan struct{}
+
+	// WaitGroup instance used to wait for the go-routine to exit.
+	wg sync.WaitGroup
+
+	// Set once Register() has created the key so Deregister() and
+	// UpdateValue() know whether there is anything to do. Guarded by mu
+	// so that two concurrent Deregister() calls can't both pass the
+	// "still registered" check and double-close stopCh.
+	registered bool
+}
+
+// Description:
+//     A constructor routine to instantiate a service registration operation.
+//
+// Parameters:
+//     @path       - A path in the etcd namespace under which the instance
+//                    key will be created.
+//     @instanceID - A unique identifier for this instance. The key created
+//                    in etcd is @path/@instanceID.
+//     @value      - The value to store in the instance's key, e.g. an
+//                    address that discovering parties can dial.
+//     @ttl        - The lifetime of the ephemeral key. The key is refreshed
+//                    well before it expires for as long as the instance
+//                    remains registered.
+//
+// Return value:
+//     1. A pointer to the ServiceRegistrar instance.
+func (ec *EtcdConnector) NewServiceRegistrar(path, instanceID, value string, ttl time.Duration) *ServiceRegistrar {
+	return &ServiceRegistrar{
+		ec:              ec,
+		servicePath:     path,
+		instanceID:      instanceID,
+		value:           value,
+		ttl:             ttl,
+		refreshInterval: ttl / 2,
+	}
+}
+
+// Description:
+//     Returns the full etcd key this registrar manages, i.e. @servicePath
+//     joined with @instanceID.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The instance key.
+func (sr *ServiceRegistrar) key() string {
+	return sr.servicePath + "/" + sr.instanceID
+}
+
+// Description:
+//     A routine to create the ephemeral instance key under @servicePath and
+//     start the background go-routine that keeps it alive by refreshing the
+//     TTL at sr.refreshInterval. If a refresh fails the go-routine re-creates
+//     the key outright, backing off exponentially between attempts.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. Error information, if any.
+func (sr *ServiceRegistrar) Register() error {
+	sr.mu.Lock()
+	value := sr.value
+	sr.mu.Unlock()
+
+	opts := &client.SetOptions{TTL: sr.ttl}
+	if _, err := sr.ec.Set(context.Background(), sr.key(), value, opts); err != nil {
+		return err
+	}
+
+	sr.stopCh = make(chan struct{})
+
+	sr.mu.Lock()
+	sr.registered = true
+	sr.mu.Unlock()
+
+	sr.wg.Add(1)
+	go sr.refreshLoop()
+
+	return nil
+}
+
+// Description:
+//     The background go-routine started by Register(). It wakes up every
+//     sr.refreshInterval and renews the instance key's TTL. A failed
+//     refresh is retried with exponential backoff (capped at sr.ttl) until
+//     it either succeeds or Deregister() is called.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     None
+func (sr *ServiceRegistrar) refreshLoop() {
+	defer sr.wg.Done()
+
+	backoff := time.Second
+
+	for {
+		select {
+		case <-sr.stopCh:
+			return
+		case <-time.After(sr.refreshInterval):
+		}
+
+		sr.mu.Lock()
+		value := sr.value
+		sr.mu.Unlock()
+
+		// Refresh the TTL in place. PrevExist makes the refresh fail, rather
+		// than create the key, if the key no longer exists; the fallback
+		// below then re-creates it.
+		opts := &client.SetOptions{TTL: sr.ttl, Refresh: true, PrevExist: client.PrevExist}
+		_, err := sr.ec.Set(context.Background(), sr.key(), "", opts)
+		if err == nil {
+			backoff = time.Second
+			continue
+		}
+
+		// The refresh failed, most likely because the key had already
+		// expired. Fall back to re-registering the key from scratch with
+		// exponential backoff between attempts.
+		for {
+			select {
+			case <-sr.stopCh:
+				return
+			case <-time.After(backoff):
+			}
+
+			sr.mu.Lock()
+			value = sr.value
+			sr.mu.Unlock()
+
+			_, err := sr.ec.Set(context.Background(), sr.key(), value, &client.SetOptions{TTL: sr.ttl})
+			if err == nil {
+				backoff = time.Second
+				break
+			}
+
+			if backoff *= 2; backoff > sr.ttl {
+				backoff = sr.ttl
+			}
+		}
+	}
+}
+
+// Description:
+//     A routine to remove the instance key from etcd and stop the
+//     background refresh go-routine. Safe to call multiple times.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. Error information, if any.
+func (sr *ServiceRegistrar) Deregister() error {
+	sr.mu.Lock()
+	if !sr.registered {
+		sr.mu.Unlock()
+		return nil
+	}
+	sr.registered = false
+	sr.mu.Unlock()
+
+	// registered was flipped to false under sr.mu above, so only one
+	// concurrent Deregister() call can reach this close.
+	close(sr.stopCh)
+	sr.wg.Wait()
+
+	_, err := sr.ec.Delete(context.Background(), sr.key(), &client.DeleteOptions{})
+	return err
+}
+
+// Description:
+//     A routine to update the value stored in the instance's key, e.g. when
+//     the instance's metadata (weight, tags, etc...) changes. The new value
+//     is written to the key immediately, and is also kept on the registrar
+//     so that a later re-registration by the refresh go-routine uses it.
+//
+// Parameters:
+//     @newVal - The new value to associate with the instance key.
+//
+// Return value:
+//     1. Error information, if any.
+func (sr *ServiceRegistrar) UpdateValue(newVal string) error {
+	sr.mu.Lock()
+	sr.value = newVal
+	registered := sr.registered
+	sr.mu.Unlock()
+
+	if !registered {
+		return nil
+	}
+
+	opts := &client.SetOptions{TTL: sr.ttl, PrevExist: client.PrevExist}
+	_, err := sr.ec.Set(context.Background(), sr.key(), newVal, opts)
+	return err
+}
@@ -51,6 +51,11 @@ type ServiceTracker struct {
 
 	// WaitGroup instance used to wait for the go-routine to exit.
 	wg *sync.WaitGroup
+
+	// Optional hook used to decode a Pair's raw Value into a user-defined
+	// instance descriptor as it is observed. Nil unless the tracker was
+	// built with NewServiceTrackerWithFactory.
+	factory Factory
 }
 
 // A structure that describes a key-value pair.
@@ -59,12 +64,36 @@ type Pair struct {
 	Value string
 }
 
+// A hook that decodes the raw Value stored in an etcd node into a
+// caller-defined instance descriptor (e.g. a struct carrying host/port/
+// tags/weight). Returning an error does not abort the update; it is
+// surfaced on the corresponding Instance's DecodeErr instead.
+type Factory func(key, value string) (interface{}, error)
+
+// A decoded instance, produced by running a Pair's Value through a
+// ServiceTracker's Factory.
+type Instance struct {
+	// The key-value pair this instance was decoded from.
+	Pair Pair
+
+	// The value returned by the Factory. Nil if DecodeErr is set.
+	Value interface{}
+
+	// Set if the Factory failed to decode Pair.Value. A decode failure
+	// for one instance does not prevent the others from being reported.
+	DecodeErr error
+}
+
 // A structure that will be sent back to the caller whenever a change
 // is observed under @servicePath.
 type TrackerData struct {
 	// An array of all active service instances represented as key-value pairs.
 	Pairs []Pair
 
+	// The Pairs above decoded via the tracker's Factory. Only populated
+	// when the tracker was built with NewServiceTrackerWithFactory.
+	Instances []Instance
+
 	// Error information, if any.
 	Err error
 }
@@ -88,6 +117,70 @@ func (ec *EtcdConnector) NewServiceTracker(path string) *ServiceTracker {
 	return st
 }
 
+// Description:
+//     A constructor routine to instantiate a service tracking operation
+//     whose TrackerData additionally carries instances decoded through
+//     @factory. This lets consumers store richer instance descriptors
+//     (e.g. JSON blobs with weights, zone, protocol) without writing a
+//     second parsing layer on top of the tracker.
+//
+// Parameters:
+//     @path    - A path in the etcd namespace under which the instances
+//                will be tracked.
+//     @factory - A hook invoked with each instance's key and raw value to
+//                decode it into a user-defined descriptor.
+//
+// Return value:
+//     1. A pointer to the ServiceTracker instance.
+func (ec *EtcdConnector) NewServiceTrackerWithFactory(path string, factory Factory) *ServiceTracker {
+	st := ec.NewServiceTracker(path)
+	st.factory = factory
+	return st
+}
+
+// Description:
+//     Reports whether this tracker was built with NewServiceTrackerWithFactory,
+//     i.e. whether its TrackerData.Instances will ever be populated. Callers
+//     that depend on decoded instances (e.g. the lb package's
+//     WeightedRoundRobin) can use this to fail fast instead of silently
+//     seeing an empty Instances slice forever.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. True if a Factory is configured.
+func (st *ServiceTracker) HasFactory() bool {
+	return st.factory != nil
+}
+
+// Description:
+//     Runs every Pair in @pairs through st.factory, if one is configured,
+//     producing the corresponding Instance slice. A decode error for one
+//     Pair is recorded on its Instance and does not affect the others.
+//
+// Parameters:
+//     @pairs - The key-value pairs to decode.
+//
+// Return value:
+//     1. The decoded instances, or nil if no Factory is configured.
+func (st *ServiceTracker) decode(pairs []Pair) []Instance {
+	if st.factory == nil {
+		return nil
+	}
+
+	instances := make([]Instance, len(pairs))
+	for i, p := range pairs {
+		inst := Instance{Pair: p}
+		inst.Value, inst.DecodeErr = st.factory(p.Key, p.Value)
+		if inst.DecodeErr != nil {
+			inst.Value = nil
+		}
+		instances[i] = inst
+	}
+	return instances
+}
+
 // Description:
 //     A routine to start the service tracking operation. This routine starts
 //     an Observer on @servicePath and waits to hear from the Observer about
@@ -164,7 +257,7 @@ func (st *ServiceTracker) Start() (<-chan TrackerData, error) {
 
 			// if anything has changed then send the new pairs to the caller.
 			if updated == true {
-				tracker <- TrackerData{Pairs: curKeyVals, Err: nil}
+				tracker <- TrackerData{Pairs: curKeyVals, Instances: st.decode(curKeyVals), Err: nil}
 			}
 		}
 
@@ -190,3 +283,126 @@ func (st *ServiceTracker) Stop() {
 	st.obsvr.Stop()
 	st.wg.Wait()
 }
+
+// The type of change that a ServiceEvent represents.
+type EventType int
+
+const (
+	// A new key appeared under @servicePath.
+	Added EventType = iota
+
+	// A previously seen key has disappeared from @servicePath.
+	Removed
+
+	// A previously seen key's value has changed.
+	Modified
+)
+
+// A structure that describes a single add/remove/update that was observed
+// under @servicePath. Unlike TrackerData, which ships the entire set of
+// Pairs on every notification, ServiceEvent carries only the delta for the
+// key that actually changed.
+type ServiceEvent struct {
+	// The kind of change this event represents.
+	Type EventType
+
+	// The node key that changed.
+	Key string
+
+	// The value the key held before the change. Empty for Added events.
+	OldValue string
+
+	// The value the key holds after the change. Empty for Removed events.
+	NewValue string
+
+	// Error information, if any. When set, the other fields are zero
+	// valued and should be ignored.
+	Err error
+}
+
+// Description:
+//     A routine to start the service tracking operation in event mode. This
+//     routine starts an Observer on @servicePath and waits to hear from the
+//     Observer about changes. Unlike Start(), which re-sends the full set of
+//     Pairs on every notification, StartEvents() keeps a cache of the
+//     instances keyed by node key and, on every notification, diffs the
+//     latest directory contents against that cache to compute per-key
+//     Added/Removed/Modified events. This lets subscribers maintain
+//     incremental state without rescanning every instance on each
+//     notification.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. A channel on which ServiceEvents will be notified.
+func (st *ServiceTracker) StartEvents() (<-chan ServiceEvent, error) {
+	// Create an outward channel on which service events will be sent.
+	events := make(chan ServiceEvent, 2)
+
+	// Start the Observer.
+	obResp, err := st.obsvr.Start(0, true)
+	if err != nil {
+		close(events)
+		return nil, err
+	}
+
+	curByKey := make(map[string]string)
+	opts := &client.GetOptions{Sort: true, Recursive: true}
+
+	// Account for the go-routine in WaitGroup.
+	st.wg.Add(1)
+
+	// Observe the changes in a go routine.
+	go func() {
+		for or := range obResp {
+			// If any error, report it back to the caller. Rely on the
+			// caller to handle the error appropriately.
+			if or.Err != nil {
+				events <- ServiceEvent{Err: or.Err}
+				continue
+			}
+
+			// Get the latest contents of @servicePath directory.
+			r, e := st.ec.Get(context.Background(), st.servicePath, opts)
+			if e != nil {
+				events <- ServiceEvent{Err: e}
+				continue
+			}
+
+			newByKey := make(map[string]string, len(r.Node.Nodes))
+			for i := 0; i < len(r.Node.Nodes); i++ {
+				newByKey[r.Node.Nodes[i].Key] = r.Node.Nodes[i].Value
+			}
+
+			// Keys present in the new set but not the old one were added;
+			// keys present in both but with a different value were
+			// modified.
+			for key, newVal := range newByKey {
+				oldVal, existed := curByKey[key]
+				if !existed {
+					events <- ServiceEvent{Type: Added, Key: key, NewValue: newVal}
+				} else if oldVal != newVal {
+					events <- ServiceEvent{Type: Modified, Key: key, OldValue: oldVal, NewValue: newVal}
+				}
+			}
+
+			// Keys present in the old set but not the new one were removed.
+			for key, oldVal := range curByKey {
+				if _, stillPresent := newByKey[key]; !stillPresent {
+					events <- ServiceEvent{Type: Removed, Key: key, OldValue: oldVal}
+				}
+			}
+
+			curByKey = newByKey
+		}
+
+		// If the observer channel is closed then close the events channel too.
+		close(events)
+
+		// Adjust the WaitGroup counter before exiting the go-routine.
+		st.wg.Done()
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v3 mirrors the etcd_recipes package, but talks to an etcd cluster
+// over the v3 API (go.etcd.io/etcd/clientv3) instead of the deprecated v2
+// client package. The v2 and v3 clients are not wire- or API-compatible, so
+// this lives as a sibling package rather than a drop-in replacement. The
+// recipes here (EtcdConnector, Observer, ServiceTracker, ServiceRegistrar)
+// keep the names of their v2 counterparts, but some signatures differ (e.g.
+// Observer.Start takes a start revision, EtcdConnector.Get takes no
+// options), and the v3 ServiceTracker has no Factory support.
+package v3
+
+import (
+	"context"
+	"time"
+
+	"go.etcd.io/etcd/clientv3"
+)
+
+// A descriptor structure for the etcd v3 connection.
+type EtcdConnector struct {
+	// The underlying clientv3 client.
+	Client *clientv3.Client
+}
+
+// Description:
+//     A constructor routine to instantiate a connection to an etcd v3
+//     cluster.
+//
+// Parameters:
+//     @endpoints   - The etcd cluster endpoints to dial.
+//     @dialTimeout - The timeout to apply while establishing the
+//                    connection.
+//
+// Return value:
+//     1. A pointer to the EtcdConnector instance.
+//     2. Error information, if any.
+func NewEtcdConnector(endpoints []string, dialTimeout time.Duration) (*EtcdConnector, error) {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: dialTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EtcdConnector{Client: cli}, nil
+}
+
+// Description:
+//     Fetches every key-value pair stored under @path.
+//
+// Parameters:
+//     @ctx  - The context governing the request.
+//     @path - The directory prefix to fetch.
+//
+// Return value:
+//     1. The etcd response.
+//     2. Error information, if any.
+func (ec *EtcdConnector) Get(ctx context.Context, path string) (*clientv3.GetResponse, error) {
+	return ec.Client.Get(ctx, path, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+}
+
+// Description:
+//     Creates or overwrites the value stored at @key.
+//
+// Parameters:
+//     @ctx   - The context governing the request.
+//     @key   - The key to write.
+//     @value - The value to store.
+//     @opts  - Additional clientv3 options, e.g. clientv3.WithLease.
+//
+// Return value:
+//     1. The etcd response.
+//     2. Error information, if any.
+func (ec *EtcdConnector) Put(ctx context.Context, key, value string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	return ec.Client.Put(ctx, key, value, opts...)
+}
+
+// Description:
+//     Deletes @key.
+//
+// Parameters:
+//     @ctx - The context governing the request.
+//     @key - The key to delete.
+//
+// Return value:
+//     1. The etcd response.
+//     2. Error information, if any.
+func (ec *EtcdConnector) Delete(ctx context.Context, key string) (*clientv3.DeleteResponse, error) {
+	return ec.Client.Delete(ctx, key)
+}
+
+// Description:
+//     Closes the underlying clientv3 connection.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. Error information, if any.
+func (ec *EtcdConnector) Close() error {
+	return ec.Client.Close()
+}
@@ -0,0 +1,177 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"time"
+
+	"go.etcd.io/etcd/clientv3"
+)
+
+// The backoff applied before re-issuing a watch after it reported an error
+// other than a compaction (e.g. an auth failure or a cluster rejecting the
+// request), and the ceiling that backoff is capped at. Without this, a
+// persistent error would have the Observer hammer the server with new
+// Watch RPCs as fast as it can reject them.
+const (
+	watchRetryBackoffInitial = time.Second
+	watchRetryBackoffMax     = 30 * time.Second
+)
+
+// OBSERVER RECIPE (v3)
+//
+// Where the v2 Observer polls etcd's index-based Watcher and leaves it to
+// the caller to re-fetch the directory on every notification, this v3
+// Observer consumes clientv3's Watch channel directly and hands the raw
+// put/delete events up to the caller. It re-establishes the watch starting
+// from the last seen revision whenever the channel closes or reports a
+// compaction, so callers never have to notice a watch being torn down and
+// restarted underneath them.
+
+// A single notification posted on the channel returned by Start().
+type ObserverResponse struct {
+	// The put/delete events delivered by this notification. Nil if Err
+	// is set.
+	Events []*clientv3.Event
+
+	// Error information, if any. Set when the watch could not be
+	// established or re-established, or when WatchResponse.Err() surfaces
+	// anything other than a compaction (compactions are retried
+	// transparently from CompactRevision and are not reported here).
+	Err error
+}
+
+// A descriptor structure for the etcd v3 watch operation.
+type Observer struct {
+	ec          *EtcdConnector
+	servicePath string
+	cancel      context.CancelFunc
+}
+
+// Description:
+//     A constructor routine to instantiate a v3 Observer.
+//
+// Parameters:
+//     @path - The directory prefix in the etcd namespace to watch.
+//
+// Return value:
+//     1. A pointer to the Observer instance.
+func (ec *EtcdConnector) NewObserver(path string) *Observer {
+	return &Observer{
+		ec:          ec,
+		servicePath: path,
+	}
+}
+
+// Description:
+//     Starts watching @servicePath from @fromRevision onward (0 means
+//     "watch from now") and posts every put/delete event it sees on the
+//     returned channel. If the watch channel closes or the server reports
+//     a compaction, the Observer transparently re-issues the watch from the
+//     latest seen revision instead of surfacing that as an error to the
+//     caller.
+//
+// Parameters:
+//     @fromRevision - The etcd revision to start watching from, or 0 to
+//                     watch from the current revision.
+//
+// Return value:
+//     1. A channel on which ObserverResponses will be posted.
+//     2. Error information, if any.
+func (o *Observer) Start(fromRevision int64) (<-chan ObserverResponse, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+
+	out := make(chan ObserverResponse, 2)
+
+	go func() {
+		defer close(out)
+
+		rev := fromRevision
+		backoff := watchRetryBackoffInitial
+		for {
+			opts := []clientv3.OpOption{clientv3.WithPrefix()}
+			if rev > 0 {
+				opts = append(opts, clientv3.WithRev(rev))
+			}
+
+			watchCh := o.ec.Client.Watch(ctx, o.servicePath, opts...)
+
+			hadErr := false
+			for wr := range watchCh {
+				if err := wr.Err(); err != nil {
+					if wr.CompactRevision > 0 {
+						// Our watch fell behind a compaction. Resume from
+						// the revision the server tells us is still
+						// available.
+						rev = wr.CompactRevision
+						break
+					}
+					hadErr = true
+					out <- ObserverResponse{Err: err}
+					continue
+				}
+
+				rev = wr.Header.Revision + 1
+				backoff = watchRetryBackoffInitial
+				out <- ObserverResponse{Events: wr.Events}
+			}
+
+			if ctx.Err() != nil {
+				// Stop() was called; exit quietly instead of re-watching.
+				return
+			}
+
+			if hadErr {
+				// The watch kept reporting errors (e.g. an auth failure or
+				// the cluster rejecting the request). Back off before
+				// re-issuing it so a persistent error doesn't turn into a
+				// tight reconnect loop hammering the server.
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+
+				if backoff *= 2; backoff > watchRetryBackoffMax {
+					backoff = watchRetryBackoffMax
+				}
+				continue
+			}
+
+			// The watch channel closed cleanly - either because of a
+			// compaction handled above, or because the server connection
+			// dropped. Either way, loop around and re-establish it from
+			// the last seen revision.
+		}
+	}()
+
+	return out, nil
+}
+
+// Description:
+//     Stops the watch go-routine started by Start().
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     None
+func (o *Observer) Stop() {
+	if o.cancel != nil {
+		o.cancel()
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/clientv3"
+)
+
+// SERVICE-REGISTRAR RECIPE (v3)
+//
+// This mirrors the v2 ServiceRegistrar recipe, but uses a clientv3 lease in
+// place of a v2 TTL key. Register() grants a lease, attaches the instance
+// key to it, and starts clientv3's KeepAlive loop to renew it; there is no
+// need for the hand-rolled refresh-at-ttl/2-with-backoff logic the v2
+// recipe needs, since clientv3.KeepAlive already keeps the lease alive for
+// as long as the client can reach the cluster and reports failure on its
+// channel when it can't.
+
+// A descriptor structure for the service registration operation.
+type ServiceRegistrar struct {
+	ec          *EtcdConnector
+	servicePath string
+	instanceID  string
+	ttl         time.Duration
+
+	// Guards value, leaseID, and registered against concurrent
+	// Register/Deregister/UpdateValue/keepAliveLoop access.
+	mu      sync.Mutex
+	value   string
+	leaseID clientv3.LeaseID
+
+	// Set once Register() has created the key so Deregister() and
+	// UpdateValue() know whether there is anything to do. Guarded by mu
+	// so that two concurrent Deregister() calls can't both pass the
+	// "still registered" check and double-cancel/Revoke.
+	registered bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Description:
+//     A constructor routine to instantiate a service registration
+//     operation.
+//
+// Parameters:
+//     @path       - A path in the etcd namespace under which the instance
+//                    key will be created.
+//     @instanceID - A unique identifier for this instance. The key created
+//                    in etcd is @path/@instanceID.
+//     @value      - The value to store in the instance's key, e.g. an
+//                    address that discovering parties can dial.
+//     @ttl        - The lease TTL. clientv3's KeepAlive renews this well
+//                    before it expires for as long as Register() remains
+//                    in effect.
+//
+// Return value:
+//     1. A pointer to the ServiceRegistrar instance.
+func (ec *EtcdConnector) NewServiceRegistrar(path, instanceID, value string, ttl time.Duration) *ServiceRegistrar {
+	return &ServiceRegistrar{
+		ec:          ec,
+		servicePath: path,
+		instanceID:  instanceID,
+		value:       value,
+		ttl:         ttl,
+	}
+}
+
+// Description:
+//     Returns the full etcd key this registrar manages, i.e. @servicePath
+//     joined with @instanceID.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The instance key.
+func (sr *ServiceRegistrar) key() string {
+	return sr.servicePath + "/" + sr.instanceID
+}
+
+// Description:
+//     Returns the lease ID currently backing the instance key.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The current lease ID.
+func (sr *ServiceRegistrar) getLeaseID() clientv3.LeaseID {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.leaseID
+}
+
+// Description:
+//     Grants a lease for sr.ttl, creates the instance key attached to that
+//     lease, and starts a go-routine that consumes clientv3's KeepAlive
+//     channel to keep the lease - and therefore the instance key - alive.
+//     If the KeepAlive channel closes (e.g. the lease expired because the
+//     client lost contact with the cluster for too long), the go-routine
+//     re-registers from scratch once the cluster is reachable again.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. Error information, if any.
+func (sr *ServiceRegistrar) Register() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	sr.cancel = cancel
+
+	if err := sr.register(ctx); err != nil {
+		cancel()
+		return err
+	}
+
+	sr.mu.Lock()
+	sr.registered = true
+	sr.mu.Unlock()
+
+	sr.wg.Add(1)
+	go sr.keepAliveLoop(ctx)
+
+	return nil
+}
+
+// Description:
+//     Grants a fresh lease for sr.ttl and creates the instance key attached
+//     to it, starting the KeepAlive channel for that lease.
+//
+// Parameters:
+//     @ctx - The context governing the lease grant, key creation, and the
+//            resulting KeepAlive channel.
+//
+// Return value:
+//     1. Error information, if any.
+func (sr *ServiceRegistrar) register(ctx context.Context) error {
+	lease, err := sr.ec.Client.Grant(ctx, int64(sr.ttl.Seconds()))
+	if err != nil {
+		return err
+	}
+
+	sr.mu.Lock()
+	value := sr.value
+	sr.mu.Unlock()
+
+	if _, err := sr.ec.Put(ctx, sr.key(), value, clientv3.WithLease(lease.ID)); err != nil {
+		return err
+	}
+
+	sr.mu.Lock()
+	sr.leaseID = lease.ID
+	sr.mu.Unlock()
+	return nil
+}
+
+// Description:
+//     The background go-routine started by Register(). It drains
+//     clientv3's KeepAlive channel for as long as the lease stays alive. If
+//     the channel closes - meaning the lease was allowed to expire - it
+//     retries register() with a fixed backoff until Deregister() is called.
+//
+// Parameters:
+//     @ctx - The context under which the lease was granted; cancelled by
+//            Deregister().
+//
+// Return value:
+//     None
+func (sr *ServiceRegistrar) keepAliveLoop(ctx context.Context) {
+	defer sr.wg.Done()
+
+	for {
+		ka, err := sr.ec.Client.KeepAlive(ctx, sr.getLeaseID())
+		if err == nil {
+			for range ka {
+				// Drain keep-alive responses; nothing to act on as long as
+				// they keep arriving.
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The KeepAlive channel closed without the context being
+		// cancelled, meaning the lease lapsed. Back off briefly and
+		// re-register from scratch.
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+
+		if err := sr.register(ctx); err != nil {
+			continue
+		}
+	}
+}
+
+// Description:
+//     Revokes the lease backing the instance key - which also deletes the
+//     key - and stops the KeepAlive go-routine. Safe to call multiple
+//     times.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. Error information, if any.
+func (sr *ServiceRegistrar) Deregister() error {
+	sr.mu.Lock()
+	if !sr.registered {
+		sr.mu.Unlock()
+		return nil
+	}
+	sr.registered = false
+	sr.mu.Unlock()
+
+	// registered was flipped to false under sr.mu above, so only one
+	// concurrent Deregister() call can reach this point.
+	sr.cancel()
+	sr.wg.Wait()
+
+	_, err := sr.ec.Client.Revoke(context.Background(), sr.getLeaseID())
+	return err
+}
+
+// Description:
+//     Updates the value stored in the instance's key. The new value is
+//     written immediately under the current lease; it does not wait for
+//     the next keep-alive.
+//
+// Parameters:
+//     @newVal - The new value to associate with the instance key.
+//
+// Return value:
+//     1. Error information, if any.
+func (sr *ServiceRegistrar) UpdateValue(newVal string) error {
+	sr.mu.Lock()
+	sr.value = newVal
+	registered := sr.registered
+	sr.mu.Unlock()
+
+	if !registered {
+		return nil
+	}
+
+	_, err := sr.ec.Put(context.Background(), sr.key(), newVal, clientv3.WithLease(sr.getLeaseID()))
+	return err
+}
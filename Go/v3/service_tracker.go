@@ -0,0 +1,288 @@
+// Copyright 2016 Asya Labs
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+// SERVICE-TRACKER RECIPE (v3)
+//
+// This mirrors the v2 ServiceTracker recipe, but is driven directly by the
+// v3 Observer's watch events instead of re-fetching and diffing the whole
+// @servicePath directory on every notification. A PUT event is applied as
+// an add/update of the corresponding key, and a DELETE event is applied as
+// a removal, so @servicePath is never re-fetched after the initial seed.
+// StartEvents() does work per notification proportional to the number of
+// keys that actually changed; Start() additionally copies and sorts the
+// full instance set into the snapshot it posts for each notification.
+
+// A structure that describes a key-value pair.
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// A structure that will be sent back to the caller whenever a change is
+// observed under @servicePath.
+type TrackerData struct {
+	// An array of all active service instances represented as key-value pairs.
+	Pairs []Pair
+
+	// Error information, if any.
+	Err error
+}
+
+// A descriptor structure for the service tracking operation.
+type ServiceTracker struct {
+	ec          *EtcdConnector
+	servicePath string
+	obsvr       *Observer
+	wg          sync.WaitGroup
+
+	mu    sync.Mutex
+	byKey map[string]string
+}
+
+// Description:
+//     A constructor routine to instantiate a service tracking operation.
+//
+// Parameters:
+//     @path - A path in the etcd namespace under which the instances will
+//             be tracked.
+//
+// Return value:
+//     1. A pointer to the ServiceTracker instance.
+func (ec *EtcdConnector) NewServiceTracker(path string) *ServiceTracker {
+	return &ServiceTracker{
+		ec:          ec,
+		servicePath: path,
+		obsvr:       ec.NewObserver(path),
+		byKey:       make(map[string]string),
+	}
+}
+
+// Description:
+//     Fetches the current contents of @servicePath and seeds st.byKey with
+//     it.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The revision just after the one the snapshot was read at, i.e.
+//        the first revision to watch from.
+//     2. Error information, if any.
+func (st *ServiceTracker) seed() (int64, error) {
+	r, err := st.ec.Get(context.Background(), st.servicePath)
+	if err != nil {
+		return 0, err
+	}
+
+	st.mu.Lock()
+	for _, kv := range r.Kvs {
+		st.byKey[string(kv.Key)] = string(kv.Value)
+	}
+	st.mu.Unlock()
+
+	return r.Header.Revision + 1, nil
+}
+
+// Description:
+//     Returns a sorted-by-key snapshot of st.byKey as a Pair slice.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. The current set of instances.
+func (st *ServiceTracker) snapshot() []Pair {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	pairs := make([]Pair, 0, len(st.byKey))
+	for k, v := range st.byKey {
+		pairs = append(pairs, Pair{Key: k, Value: v})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	return pairs
+}
+
+// Description:
+//     A routine to start the service tracking operation. This seeds the
+//     tracker with the current contents of @servicePath, then starts an
+//     Observer from that point onward and applies each PUT/DELETE event it
+//     sees directly to the cached instance set, posting the updated
+//     snapshot to the caller. No full re-Get of @servicePath is performed
+//     after the initial seed.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. A channel on which TrackerData will be notified.
+//     2. Error information, if any.
+func (st *ServiceTracker) Start() (<-chan TrackerData, error) {
+	tracker := make(chan TrackerData, 2)
+
+	rev, err := st.seed()
+	if err != nil {
+		close(tracker)
+		return nil, err
+	}
+
+	obResp, err := st.obsvr.Start(rev)
+	if err != nil {
+		close(tracker)
+		return nil, err
+	}
+
+	// Post the seeded snapshot before applying any deltas.
+	tracker <- TrackerData{Pairs: st.snapshot()}
+
+	st.wg.Add(1)
+	go func() {
+		defer st.wg.Done()
+		defer close(tracker)
+
+		for or := range obResp {
+			if or.Err != nil {
+				tracker <- TrackerData{Err: or.Err}
+				continue
+			}
+
+			st.mu.Lock()
+			for _, ev := range or.Events {
+				key := string(ev.Kv.Key)
+				if ev.Type == mvccpb.DELETE {
+					delete(st.byKey, key)
+				} else {
+					st.byKey[key] = string(ev.Kv.Value)
+				}
+			}
+			st.mu.Unlock()
+
+			tracker <- TrackerData{Pairs: st.snapshot()}
+		}
+	}()
+
+	return tracker, nil
+}
+
+// Description:
+//     A routine to stop the service tracking operation.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     None
+func (st *ServiceTracker) Stop() {
+	st.obsvr.Stop()
+	st.wg.Wait()
+}
+
+// Description:
+//     A routine to start the service tracking operation in event mode,
+//     delivering each PUT as an Added/Modified ServiceEvent and each DELETE
+//     as a Removed ServiceEvent as it comes off the watch, with no
+//     intermediate diffing step.
+//
+// Parameters:
+//     None
+//
+// Return value:
+//     1. A channel on which ServiceEvents will be notified.
+//     2. Error information, if any.
+func (st *ServiceTracker) StartEvents() (<-chan ServiceEvent, error) {
+	events := make(chan ServiceEvent, 2)
+
+	rev, err := st.seed()
+	if err != nil {
+		close(events)
+		return nil, err
+	}
+
+	obResp, err := st.obsvr.Start(rev)
+	if err != nil {
+		close(events)
+		return nil, err
+	}
+
+	st.wg.Add(1)
+	go func() {
+		defer st.wg.Done()
+		defer close(events)
+
+		for or := range obResp {
+			if or.Err != nil {
+				events <- ServiceEvent{Err: or.Err}
+				continue
+			}
+
+			for _, ev := range or.Events {
+				key := string(ev.Kv.Key)
+
+				st.mu.Lock()
+				oldVal, existed := st.byKey[key]
+				if ev.Type == mvccpb.DELETE {
+					delete(st.byKey, key)
+				} else {
+					st.byKey[key] = string(ev.Kv.Value)
+				}
+				st.mu.Unlock()
+
+				switch {
+				case ev.Type == mvccpb.DELETE:
+					events <- ServiceEvent{Type: Removed, Key: key, OldValue: oldVal}
+				case !existed:
+					events <- ServiceEvent{Type: Added, Key: key, NewValue: string(ev.Kv.Value)}
+				default:
+					events <- ServiceEvent{Type: Modified, Key: key, OldValue: oldVal, NewValue: string(ev.Kv.Value)}
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// The type of change that a ServiceEvent represents.
+type EventType int
+
+const (
+	// A new key appeared under @servicePath.
+	Added EventType = iota
+
+	// A previously seen key has disappeared from @servicePath.
+	Removed
+
+	// A previously seen key's value has changed.
+	Modified
+)
+
+// A structure that describes a single add/remove/update observed under
+// @servicePath.
+type ServiceEvent struct {
+	Type     EventType
+	Key      string
+	OldValue string
+	NewValue string
+	Err      error
+}